@@ -3,96 +3,317 @@ package provider
 import (
 	"context"
 	"fmt"
-	"path"
-	"runtime"
 	"sync"
+	"time"
 
+	hclog "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov5"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform/configs/configschema"
 	"github.com/hashicorp/terraform/providers"
 	"github.com/hashicorp/terraform/tfdiags"
-	"github.com/pkg/errors"
 	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 	ctyjson "github.com/zclconf/go-cty/cty/json"
 	"github.com/zclconf/go-cty/cty/msgpack"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-// GRPCClient is an inmemory implementation of the TF GRPC
+// GRPCClient is an inmemory implementation of the TF GRPC protocol v5 client
 type GRPCClient struct {
 	NopProvider
 	server *schema.GRPCProviderServer
 
 	mu      sync.Mutex
 	schemas providers.GetSchemaResponse
+
+	diagnostics
 }
 
-func NewGRPCClient(pv *schema.Provider) *GRPCClient {
+func NewGRPCClient(providerName string, pv *schema.Provider, opts ...ClientOption) *GRPCClient {
 	sv := schema.NewGRPCProviderServer(pv)
-	return &GRPCClient{
-		server: sv,
+	c := &GRPCClient{
+		server:      sv,
+		diagnostics: diagnostics{providerName: providerName, codec: MsgPackCodec{}},
+	}
+	for _, opt := range opts {
+		opt(&c.diagnostics)
+	}
+	return c
+}
+
+// Client is implemented by both GRPCClient and GRPCClientV6. It extends
+// providers.Interface with the batch import/read and diagnostic-history
+// surface this package adds on top of it, so a caller driving a
+// large-scale import doesn't have to type-assert the providers.Interface
+// NewClient returns back to a concrete client type to reach them -
+// whichever protocol version the underlying provider speaks.
+type Client interface {
+	providers.Interface
+
+	// ImportBatch and ReadBatch run the same-named single-resource call
+	// across many requests, fanning the work out across concurrency
+	// goroutines instead of serially. See GRPCClient.ImportBatch and
+	// GRPCClient.ReadBatch for the cancellation and retry semantics.
+	ImportBatch(ctx context.Context, reqs []providers.ImportResourceStateRequest, concurrency int) (map[string]providers.ImportResourceStateResponse, map[string]tfdiags.Diagnostics)
+	ReadBatch(ctx context.Context, reqs []providers.ReadResourceRequest, concurrency int) (map[int]providers.ReadResourceResponse, map[int]tfdiags.Diagnostics)
+
+	// Diagnostics returns every ProviderDiagnostic recorded by the client
+	// so far, in the order they occurred.
+	Diagnostics() []ProviderDiagnostic
+}
+
+// NewClient inspects pv and returns the provider client wrapper for whichever
+// plugin protocol it speaks. Providers built on terraform-plugin-sdk (v2)
+// expose a *schema.Provider and are served over protocol v5; providers that
+// have migrated to terraform-plugin-go's v6 server implementation are
+// detected via the tfprotov6.ProviderServer interface. This lets terracognita
+// import from either generation of provider without callers having to know
+// which protocol a given provider speaks. providerName is stored on the
+// resulting client and stamped onto every ProviderDiagnostic it produces.
+func NewClient(providerName string, pv interface{}, opts ...ClientOption) (Client, error) {
+	switch p := pv.(type) {
+	case *schema.Provider:
+		return NewGRPCClient(providerName, p, opts...), nil
+	case tfprotov6.ProviderServer:
+		return NewGRPCClientV6(providerName, p, opts...), nil
+	case func() tfprotov6.ProviderServer:
+		return NewGRPCClientV6(providerName, p(), opts...), nil
+	default:
+		return nil, fmt.Errorf("unsupported provider factory type %T: expected *schema.Provider (protocol v5) or tfprotov6.ProviderServer (protocol v6)", pv)
+	}
+}
+
+// ProviderDiagnostic is a diagnostic produced by a provider plugin call,
+// annotated with the context a bare tfdiags.Diagnostic loses: which
+// provider and resource type it came from, and the attribute path it
+// applies to. A CLI layer can collect these (via GRPCClient.Diagnostics /
+// GRPCClientV6.Diagnostics) and group or pretty-print them per-provider
+// instead of relaying the plugin's unattributed Summary text.
+type ProviderDiagnostic struct {
+	ProviderName string
+	ResourceType string
+	Summary      string
+	Detail       string
+	Attribute    *tftypes.AttributePath
+
+	severity tfdiags.Severity
+}
+
+// Error implements the error interface so a ProviderDiagnostic can be
+// appended directly to a tfdiags.Diagnostics the same way a plain error
+// would be.
+func (d ProviderDiagnostic) Error() string {
+	msg := fmt.Sprintf("%s/%s: %s", d.ProviderName, d.ResourceType, d.Summary)
+	if d.Attribute != nil {
+		msg = fmt.Sprintf("%s (at %s)", msg, d.Attribute)
+	}
+	if d.Detail != "" {
+		msg = fmt.Sprintf("%s: %s", msg, d.Detail)
 	}
+	return msg
 }
 
-func (c *GRPCClient) ReadResource(r providers.ReadResourceRequest) (resp providers.ReadResourceResponse) {
+// ProviderDiagnostic also implements tfdiags.Diagnostic directly, rather
+// than relying on the error fallback in tfdiags.Diagnostics.Append, which
+// always forces the severity to tfdiags.Error. Without this, a provider
+// warning came back from Append as an error, and a resource that only
+// produced warnings during ImportBatch was wrongly recorded as failed.
+
+// Severity implements tfdiags.Diagnostic.
+func (d ProviderDiagnostic) Severity() tfdiags.Severity {
+	return d.severity
+}
+
+// Description implements tfdiags.Diagnostic.
+func (d ProviderDiagnostic) Description() tfdiags.Description {
+	summary := fmt.Sprintf("%s/%s: %s", d.ProviderName, d.ResourceType, d.Summary)
+	detail := d.Detail
+	if d.Attribute != nil {
+		detail = fmt.Sprintf("At %s: %s", d.Attribute, detail)
+	}
+	return tfdiags.Description{Summary: summary, Detail: detail}
+}
+
+// Source implements tfdiags.Diagnostic. A ProviderDiagnostic has no HCL
+// source location of its own, so this is always the zero value.
+func (d ProviderDiagnostic) Source() tfdiags.Source {
+	return tfdiags.Source{}
+}
+
+// FromExpr implements tfdiags.Diagnostic.
+func (d ProviderDiagnostic) FromExpr() *tfdiags.FromExpr {
+	return nil
+}
+
+// ExtraInfo implements tfdiags.Diagnostic.
+func (d ProviderDiagnostic) ExtraInfo() interface{} {
+	return nil
+}
+
+// ClientOption configures optional behavior shared by GRPCClient and
+// GRPCClientV6 at construction time.
+type ClientOption func(*diagnostics)
+
+// WithLogger streams every diagnostic the client produces to logger, in
+// addition to returning it through the normal providers.Interface methods.
+// This is useful for structured logging during long ImportBatch runs,
+// where diagnostics would otherwise only surface once the whole run
+// finishes.
+func WithLogger(logger hclog.Logger) ClientOption {
+	return func(d *diagnostics) {
+		d.logger = logger
+	}
+}
+
+// WithCodec selects the DynamicValueCodec a GRPCClient uses to encode
+// requests and decode responses. The default, used when this option is
+// omitted, is MsgPack. Selecting JSONCodec instead makes captured
+// request/response payloads human-readable, which helps when debugging a
+// failing import or snapshotting payloads for replay-based testing.
+func WithCodec(codec DynamicValueCodec) ClientOption {
+	return func(d *diagnostics) {
+		d.codec = codec
+	}
+}
+
+// diagnostics is embedded by both GRPCClient and GRPCClientV6. It gives
+// each protocol version identical bookkeeping for provider-aware
+// diagnostics (the provider name, the recorded ProviderDiagnostic history,
+// and an optional hclog.Logger), plus the codec GRPCClient uses to encode
+// and decode DynamicValue payloads, without duplicating it per version.
+type diagnostics struct {
+	providerName string
+	logger       hclog.Logger
+	codec        DynamicValueCodec
+
+	mu      sync.Mutex
+	records []ProviderDiagnostic
+}
+
+// Diagnostics returns every ProviderDiagnostic recorded so far, in the
+// order they occurred.
+func (d *diagnostics) Diagnostics() []ProviderDiagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	out := make([]ProviderDiagnostic, len(d.records))
+	copy(out, d.records)
+	return out
+}
+
+// record builds a ProviderDiagnostic for a single plugin diagnostic, saves
+// it, streams it to the configured logger if any, and returns it so the
+// caller can append it directly to a tfdiags.Diagnostics.
+func (d *diagnostics) record(resourceType string, severity tfdiags.Severity, summary, detail string, attribute *tftypes.AttributePath) ProviderDiagnostic {
+	pd := ProviderDiagnostic{
+		ProviderName: d.providerName,
+		ResourceType: resourceType,
+		Summary:      summary,
+		Detail:       detail,
+		Attribute:    attribute,
+		severity:     severity,
+	}
+
+	d.mu.Lock()
+	d.records = append(d.records, pd)
+	d.mu.Unlock()
+
+	if d.logger != nil {
+		log := d.logger.Warn
+		if severity == tfdiags.Error {
+			log = d.logger.Error
+		}
+		log(summary, "provider", d.providerName, "resource_type", resourceType, "detail", detail, "attribute", attribute)
+	}
+
+	return pd
+}
+
+func (c *GRPCClient) ReadResource(r providers.ReadResourceRequest) providers.ReadResourceResponse {
+	resp, _ := c.readResource(context.Background(), r)
+	return resp
+}
+
+// readResource is the context-aware implementation behind ReadResource. It
+// is split out so ImportBatch (and any other caller that owns a
+// cancellable context) can thread it through the gRPC call instead of the
+// hard-coded context.Background() the exported method uses. The returned
+// error is the raw transport error, if any, so retry logic can inspect its
+// status code without having to reparse the diagnostics it was wrapped
+// into.
+func (c *GRPCClient) readResource(ctx context.Context, r providers.ReadResourceRequest) (resp providers.ReadResourceResponse, transportErr error) {
 	resSchema := c.getResourceSchema(r.TypeName)
 	metaSchema := c.getProviderMetaSchema()
 
-	mp, err := msgpack.Marshal(r.PriorState, resSchema.Block.ImpliedType())
+	currentState, err := c.codec.Encode(r.PriorState, resSchema.Block.ImpliedType())
 	if err != nil {
 		resp.Diagnostics = resp.Diagnostics.Append(err)
-		return resp
+		return resp, nil
 	}
 
 	protoReq := &tfprotov5.ReadResourceRequest{
 		TypeName:     r.TypeName,
-		CurrentState: &tfprotov5.DynamicValue{MsgPack: mp},
+		CurrentState: dynamicValueToV5(currentState),
 		Private:      r.Private,
 	}
 
 	if metaSchema.Block != nil {
-		metaMP, err := msgpack.Marshal(r.ProviderMeta, metaSchema.Block.ImpliedType())
+		providerMeta, err := c.codec.Encode(r.ProviderMeta, metaSchema.Block.ImpliedType())
 		if err != nil {
 			resp.Diagnostics = resp.Diagnostics.Append(err)
-			return resp
+			return resp, nil
 		}
-		protoReq.ProviderMeta = &tfprotov5.DynamicValue{MsgPack: metaMP}
+		protoReq.ProviderMeta = dynamicValueToV5(providerMeta)
 	}
 
-	protoResp, err := c.server.ReadResource(context.Background(), protoReq)
+	protoResp, err := c.server.ReadResource(ctx, protoReq)
 	if err != nil {
-		resp.Diagnostics = resp.Diagnostics.Append(grpcErr(err))
-		return resp
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("ReadResource", r.TypeName, err))
+		return resp, err
 	}
 	for _, d := range protoResp.Diagnostics {
-		resp.Diagnostics = resp.Diagnostics.Append(errors.New(d.Summary))
+		pd := c.record(r.TypeName, severityFromProtoV5(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
 	}
 
-	state, err := decodeDynamicValue(protoResp.NewState, resSchema.Block.ImpliedType())
+	state, err := c.codec.Decode(dynamicValueFromV5(protoResp.NewState), resSchema.Block.ImpliedType())
 	if err != nil {
 		resp.Diagnostics = resp.Diagnostics.Append(err)
-		return resp
+		return resp, nil
 	}
+	state, valDiags := validateDecoded(state, resSchema.Block)
+	resp.Diagnostics = resp.Diagnostics.Append(valDiags)
 	resp.NewState = state
 	resp.Private = protoResp.Private
 
+	return resp, nil
+}
+
+func (c *GRPCClient) ImportResourceState(r providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	resp, _ := c.importResourceState(context.Background(), r)
 	return resp
 }
 
-func (c *GRPCClient) ImportResourceState(r providers.ImportResourceStateRequest) (resp providers.ImportResourceStateResponse) {
+// importResourceState is the context-aware implementation behind
+// ImportResourceState; see readResource for why it is split out and what
+// the transportErr return is for.
+func (c *GRPCClient) importResourceState(ctx context.Context, r providers.ImportResourceStateRequest) (resp providers.ImportResourceStateResponse, transportErr error) {
 	protoReq := &tfprotov5.ImportResourceStateRequest{
 		TypeName: r.TypeName,
 		ID:       r.ID,
 	}
 
-	protoResp, err := c.server.ImportResourceState(context.Background(), protoReq)
+	protoResp, err := c.server.ImportResourceState(ctx, protoReq)
 	if err != nil {
-		resp.Diagnostics = resp.Diagnostics.Append(grpcErr(err))
-		return resp
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("ImportResourceState", r.TypeName, err))
+		return resp, err
 	}
 	for _, d := range protoResp.Diagnostics {
-		resp.Diagnostics = resp.Diagnostics.Append(errors.New(d.Summary))
+		pd := c.record(r.TypeName, severityFromProtoV5(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
 	}
 
 	for _, imported := range protoResp.ImportedResources {
@@ -102,17 +323,408 @@ func (c *GRPCClient) ImportResourceState(r providers.ImportResourceStateRequest)
 		}
 
 		resSchema := c.getResourceSchema(resource.TypeName)
-		state, err := decodeDynamicValue(imported.State, resSchema.Block.ImpliedType())
+		state, err := c.codec.Decode(dynamicValueFromV5(imported.State), resSchema.Block.ImpliedType())
 		if err != nil {
 			resp.Diagnostics = resp.Diagnostics.Append(err)
-			return resp
+			return resp, nil
 		}
+		state, valDiags := validateDecoded(state, resSchema.Block)
+		resp.Diagnostics = resp.Diagnostics.Append(valDiags)
 		resource.State = state
 		resp.ImportedResources = append(resp.ImportedResources, resource)
 	}
 
+	return resp, nil
+}
+
+func (c *GRPCClient) PlanResourceChange(r providers.PlanResourceChangeRequest) providers.PlanResourceChangeResponse {
+	resp, _ := c.planResourceChange(context.Background(), r)
+	return resp
+}
+
+// planResourceChange is the context-aware implementation behind
+// PlanResourceChange; see readResource for why it is split out and what
+// the transportErr return is for.
+func (c *GRPCClient) planResourceChange(ctx context.Context, r providers.PlanResourceChangeRequest) (resp providers.PlanResourceChangeResponse, transportErr error) {
+	resSchema := c.getResourceSchema(r.TypeName)
+	metaSchema := c.getProviderMetaSchema()
+
+	priorState, err := c.codec.Encode(r.PriorState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	proposedNewState, err := c.codec.Encode(r.ProposedNewState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	config, err := c.codec.Encode(r.Config, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+
+	protoReq := &tfprotov5.PlanResourceChangeRequest{
+		TypeName:         r.TypeName,
+		PriorState:       dynamicValueToV5(priorState),
+		ProposedNewState: dynamicValueToV5(proposedNewState),
+		Config:           dynamicValueToV5(config),
+		PriorPrivate:     r.PriorPrivate,
+	}
+
+	if metaSchema.Block != nil {
+		providerMeta, err := c.codec.Encode(r.ProviderMeta, metaSchema.Block.ImpliedType())
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp, nil
+		}
+		protoReq.ProviderMeta = dynamicValueToV5(providerMeta)
+	}
+
+	protoResp, err := c.server.PlanResourceChange(ctx, protoReq)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("PlanResourceChange", r.TypeName, err))
+		return resp, err
+	}
+	for _, d := range protoResp.Diagnostics {
+		pd := c.record(r.TypeName, severityFromProtoV5(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
+	}
+
+	state, err := c.codec.Decode(dynamicValueFromV5(protoResp.PlannedState), resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	state, valDiags := validateDecoded(state, resSchema.Block)
+	resp.Diagnostics = resp.Diagnostics.Append(valDiags)
+	resp.PlannedState = state
+	resp.PlannedPrivate = protoResp.PlannedPrivate
+	resp.LegacyTypeSystem = protoResp.UnsafeToUseLegacyTypeSystem
+	for _, p := range protoResp.RequiresReplace {
+		path, err := p.ToTerraformPath()
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			continue
+		}
+		resp.RequiresReplace = append(resp.RequiresReplace, path)
+	}
+
+	return resp, nil
+}
+
+func (c *GRPCClient) ApplyResourceChange(r providers.ApplyResourceChangeRequest) providers.ApplyResourceChangeResponse {
+	resp, _ := c.applyResourceChange(context.Background(), r)
+	return resp
+}
+
+// applyResourceChange is the context-aware implementation behind
+// ApplyResourceChange; see readResource for why it is split out and what
+// the transportErr return is for.
+func (c *GRPCClient) applyResourceChange(ctx context.Context, r providers.ApplyResourceChangeRequest) (resp providers.ApplyResourceChangeResponse, transportErr error) {
+	resSchema := c.getResourceSchema(r.TypeName)
+	metaSchema := c.getProviderMetaSchema()
+
+	priorState, err := c.codec.Encode(r.PriorState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	plannedState, err := c.codec.Encode(r.PlannedState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	config, err := c.codec.Encode(r.Config, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+
+	protoReq := &tfprotov5.ApplyResourceChangeRequest{
+		TypeName:       r.TypeName,
+		PriorState:     dynamicValueToV5(priorState),
+		PlannedState:   dynamicValueToV5(plannedState),
+		Config:         dynamicValueToV5(config),
+		PlannedPrivate: r.PlannedPrivate,
+	}
+
+	if metaSchema.Block != nil {
+		providerMeta, err := c.codec.Encode(r.ProviderMeta, metaSchema.Block.ImpliedType())
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp, nil
+		}
+		protoReq.ProviderMeta = dynamicValueToV5(providerMeta)
+	}
+
+	protoResp, err := c.server.ApplyResourceChange(ctx, protoReq)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("ApplyResourceChange", r.TypeName, err))
+		return resp, err
+	}
+	for _, d := range protoResp.Diagnostics {
+		pd := c.record(r.TypeName, severityFromProtoV5(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
+	}
+
+	state, err := c.codec.Decode(dynamicValueFromV5(protoResp.NewState), resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	state, valDiags := validateDecoded(state, resSchema.Block)
+	resp.Diagnostics = resp.Diagnostics.Append(valDiags)
+	resp.NewState = state
+	resp.Private = protoResp.Private
+	resp.LegacyTypeSystem = protoResp.UnsafeToUseLegacyTypeSystem
+
+	return resp, nil
+}
+
+// UpgradeResourceState forwards to the wrapped schema.GRPCProviderServer so
+// that a resource instance state saved by an older schema version can be
+// brought up to date with the provider's current schema before it is used
+// for any further processing. This replaces the no-op inherited from
+// NopProvider, which returned an empty response with no upgraded state.
+func (c *GRPCClient) UpgradeResourceState(r providers.UpgradeResourceStateRequest) providers.UpgradeResourceStateResponse {
+	resp, _ := c.upgradeResourceState(context.Background(), r)
 	return resp
+}
+
+// upgradeResourceState is the context-aware implementation behind
+// UpgradeResourceState; see readResource for why it is split out and what
+// the transportErr return is for.
+func (c *GRPCClient) upgradeResourceState(ctx context.Context, r providers.UpgradeResourceStateRequest) (resp providers.UpgradeResourceStateResponse, transportErr error) {
+	resSchema := c.getResourceSchema(r.TypeName)
+
+	protoReq := &tfprotov5.UpgradeResourceStateRequest{
+		TypeName: r.TypeName,
+		Version:  int64(r.Version),
+		RawState: &tfprotov5.RawState{
+			JSON:    r.RawStateJSON,
+			Flatmap: r.RawStateFlatmap,
+		},
+	}
+
+	protoResp, err := c.server.UpgradeResourceState(ctx, protoReq)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("UpgradeResourceState", r.TypeName, err))
+		return resp, err
+	}
+	for _, d := range protoResp.Diagnostics {
+		pd := c.record(r.TypeName, severityFromProtoV5(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
+	}
+
+	state, err := c.codec.Decode(dynamicValueFromV5(protoResp.UpgradedState), resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	state, valDiags := validateDecoded(state, resSchema.Block)
+	resp.Diagnostics = resp.Diagnostics.Append(valDiags)
+	resp.UpgradedState = state
 
+	return resp, nil
+}
+
+const (
+	// importBatchRetries is how many additional attempts ImportBatch makes
+	// for a single resource after a transient codes.Unavailable error,
+	// before giving up and reporting it as failed.
+	importBatchRetries = 3
+	// importBatchBaseBackoff is the delay before the first retry; it
+	// doubles after each subsequent attempt.
+	importBatchBaseBackoff = 250 * time.Millisecond
+)
+
+// isRetryableTransportErr reports whether err is the transient
+// codes.Unavailable grpcErr treats as a plugin crash or restart, which
+// importResourceStateWithRetry and readResourceWithRetry (on both
+// GRPCClient and GRPCClientV6) retry rather than reporting as failed
+// immediately.
+func isRetryableTransportErr(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// ImportBatch runs ImportResourceState for each request in reqs, fanning
+// the work out across concurrency goroutines instead of importing
+// thousands of AWS/GCP resources one at a time. ctx is threaded through
+// every gRPC call: once it is cancelled, requests that have not yet
+// started are abandoned and in-flight ones return as soon as the plugin
+// call unblocks. Requests that fail with a transient "plugin did not
+// respond" error are retried with exponential backoff before being
+// reported as failed.
+//
+// Diagnostics are returned keyed by resource address (TypeName + "." + ID)
+// rather than flattened, so a caller driving a large import run can tell
+// exactly which resources failed instead of having to match errors back
+// to requests itself.
+func (c *GRPCClient) ImportBatch(ctx context.Context, reqs []providers.ImportResourceStateRequest, concurrency int) (map[string]providers.ImportResourceStateResponse, map[string]tfdiags.Diagnostics) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make(map[string]providers.ImportResourceStateResponse, len(reqs))
+	diags := make(map[string]tfdiags.Diagnostics)
+	var mu sync.Mutex
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+reqLoop:
+	for _, r := range reqs {
+		r := r
+		addr := r.TypeName + "." + r.ID
+
+		// ctx is checked before the select because, when both of its cases
+		// are ready, select picks one at random.
+		acquired := false
+		if ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case sem <- struct{}{}:
+				acquired = true
+			}
+		}
+		if !acquired {
+			mu.Lock()
+			diags[addr] = diags[addr].Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Import cancelled",
+				fmt.Sprintf("The import of %s was cancelled before it started.", addr),
+			))
+			mu.Unlock()
+			continue reqLoop
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp := c.importResourceStateWithRetry(ctx, r)
+
+			mu.Lock()
+			results[addr] = resp
+			if resp.Diagnostics.HasErrors() {
+				diags[addr] = resp.Diagnostics
+			}
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results, diags
+}
+
+// importResourceStateWithRetry calls importResourceState, retrying with
+// exponential backoff while the transport error is the transient
+// codes.Unavailable grpcErr identifies as a plugin crash or restart. It
+// gives up early if ctx is cancelled while waiting between attempts.
+func (c *GRPCClient) importResourceStateWithRetry(ctx context.Context, r providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	backoff := importBatchBaseBackoff
+	for attempt := 0; ; attempt++ {
+		resp, transportErr := c.importResourceState(ctx, r)
+		if !isRetryableTransportErr(transportErr) || attempt >= importBatchRetries {
+			return resp
+		}
+
+		select {
+		case <-ctx.Done():
+			return resp
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// ReadBatch runs ReadResource for each request in reqs, fanning the work
+// out across concurrency goroutines the same way ImportBatch does for
+// imports, so refreshing thousands of resources ahead of a plan doesn't
+// happen one at a time either. Cancellation and retry semantics are
+// identical to ImportBatch; see it for details.
+//
+// ReadResourceRequest has no identifier of its own the way
+// ImportResourceStateRequest has ID, so results and diagnostics are keyed
+// by the request's position in reqs rather than by resource address.
+func (c *GRPCClient) ReadBatch(ctx context.Context, reqs []providers.ReadResourceRequest, concurrency int) (map[int]providers.ReadResourceResponse, map[int]tfdiags.Diagnostics) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make(map[int]providers.ReadResourceResponse, len(reqs))
+	diags := make(map[int]tfdiags.Diagnostics)
+	var mu sync.Mutex
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+reqLoop:
+	for i, r := range reqs {
+		i, r := i, r
+
+		// See ImportBatch for why ctx is checked before the select.
+		acquired := false
+		if ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case sem <- struct{}{}:
+				acquired = true
+			}
+		}
+		if !acquired {
+			mu.Lock()
+			diags[i] = diags[i].Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Read cancelled",
+				fmt.Sprintf("The read of %s was cancelled before it started.", r.TypeName),
+			))
+			mu.Unlock()
+			continue reqLoop
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp := c.readResourceWithRetry(ctx, r)
+
+			mu.Lock()
+			results[i] = resp
+			if resp.Diagnostics.HasErrors() {
+				diags[i] = resp.Diagnostics
+			}
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results, diags
+}
+
+// readResourceWithRetry calls readResource, retrying with exponential
+// backoff while the transport error is the transient codes.Unavailable
+// grpcErr identifies as a plugin crash or restart. It gives up early if ctx
+// is cancelled while waiting between attempts.
+func (c *GRPCClient) readResourceWithRetry(ctx context.Context, r providers.ReadResourceRequest) providers.ReadResourceResponse {
+	backoff := importBatchBaseBackoff
+	for attempt := 0; ; attempt++ {
+		resp, transportErr := c.readResource(ctx, r)
+		if !isRetryableTransportErr(transportErr) || attempt >= importBatchRetries {
+			return resp
+		}
+
+		select {
+		case <-ctx.Done():
+			return resp
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
 }
 
 // getSchema is used internally to get the saved provider schema.  The schema
@@ -245,8 +857,69 @@ func (np *NopProvider) Close() error {
 	return nil
 }
 
-// Decode a DynamicValue from either the JSON or MsgPack encoding.
-func decodeDynamicValue(v *tfprotov5.DynamicValue, ty cty.Type) (cty.Value, error) {
+// dynamicValue is a version-agnostic view over tfprotov5.DynamicValue and
+// tfprotov6.DynamicValue. Both proto versions wrap the same MsgPack/JSON
+// payload shape, so the encode/decode helpers below only need to know about
+// this shared shape rather than either generated type.
+type dynamicValue struct {
+	MsgPack []byte
+	JSON    []byte
+}
+
+// dynamicValueFromV5 adapts a protocol v5 DynamicValue to the version-agnostic
+// shape, returning nil for a nil input.
+func dynamicValueFromV5(v *tfprotov5.DynamicValue) *dynamicValue {
+	if v == nil {
+		return nil
+	}
+	return &dynamicValue{MsgPack: v.MsgPack, JSON: v.JSON}
+}
+
+// dynamicValueToV5 adapts the version-agnostic shape to a protocol v5
+// DynamicValue, returning nil for a nil input. It is the inverse of
+// dynamicValueFromV5, used by GRPCClient to hand a DynamicValueCodec's
+// output to the wrapped GRPCProviderServer.
+func dynamicValueToV5(v *dynamicValue) *tfprotov5.DynamicValue {
+	if v == nil {
+		return nil
+	}
+	return &tfprotov5.DynamicValue{MsgPack: v.MsgPack, JSON: v.JSON}
+}
+
+// severityFromProtoV5 maps a protocol v5 diagnostic severity onto the
+// tfdiags.Severity used throughout terraform core. Anything that isn't
+// explicitly a warning is treated as an error, matching how core itself
+// treats DiagnosticSeverityInvalid.
+func severityFromProtoV5(s tfprotov5.DiagnosticSeverity) tfdiags.Severity {
+	if s == tfprotov5.DiagnosticSeverityWarning {
+		return tfdiags.Warning
+	}
+	return tfdiags.Error
+}
+
+// dynamicValueFromV6 adapts a protocol v6 DynamicValue to the version-agnostic
+// shape, returning nil for a nil input.
+func dynamicValueFromV6(v *tfprotov6.DynamicValue) *dynamicValue {
+	if v == nil {
+		return nil
+	}
+	return &dynamicValue{MsgPack: v.MsgPack, JSON: v.JSON}
+}
+
+// dynamicValueToV6 adapts the version-agnostic shape to a protocol v6
+// DynamicValue, returning nil for a nil input. It is the inverse of
+// dynamicValueFromV6, used by GRPCClientV6 to hand a DynamicValueCodec's
+// output to the wrapped tfprotov6.ProviderServer.
+func dynamicValueToV6(v *dynamicValue) *tfprotov6.DynamicValue {
+	if v == nil {
+		return nil
+	}
+	return &tfprotov6.DynamicValue{MsgPack: v.MsgPack, JSON: v.JSON}
+}
+
+// decodeDynamicValue decodes a DynamicValue from either the JSON or MsgPack
+// encoding, regardless of which plugin protocol version produced it.
+func decodeDynamicValue(v *dynamicValue, ty cty.Type) (cty.Value, error) {
 	// always return a valid value
 	var err error
 	res := cty.NullVal(ty)
@@ -263,61 +936,223 @@ func decodeDynamicValue(v *tfprotov5.DynamicValue, ty cty.Type) (cty.Value, erro
 	return res, err
 }
 
-// grpcErr extracts some known error types and formats them into better
-// representations for core. This must only be called from plugin methods.
-// Since we don't use RPC status errors for the plugin protocol, these do not
-// contain any useful details, and we can return some text that at least
-// indicates the plugin call and possible error condition.
-func grpcErr(err error) (diags tfdiags.Diagnostics) {
-	if err == nil {
-		return
+// DynamicValueCodec encodes and decodes the DynamicValue payloads a client
+// exchanges with a provider plugin, independent of which protocol version
+// carries them on the wire. GRPCClient and GRPCClientV6 are each
+// constructed with one (MsgPackCodec by default, see WithCodec), converting
+// to and from their own protocol's DynamicValue type at the call site via
+// dynamicValueFromV5/dynamicValueFromV6 and dynamicValueToV5/
+// dynamicValueToV6. A caller can switch to JSONCodec to get human-readable
+// request/response payloads instead of always emitting MsgPack.
+type DynamicValueCodec interface {
+	Encode(v cty.Value, ty cty.Type) (*dynamicValue, error)
+	Decode(v *dynamicValue, ty cty.Type) (cty.Value, error)
+}
+
+// MsgPackCodec is the DynamicValueCodec used unless WithCodec says
+// otherwise. It is what terraform core itself speaks on the wire with both
+// SDKv2 and terraform-plugin-go providers.
+type MsgPackCodec struct{}
+
+// Encode implements DynamicValueCodec.
+func (MsgPackCodec) Encode(v cty.Value, ty cty.Type) (*dynamicValue, error) {
+	mp, err := msgpack.Marshal(v, ty)
+	if err != nil {
+		return nil, err
 	}
+	return &dynamicValue{MsgPack: mp}, nil
+}
 
-	// extract the method name from the caller.
-	pc, _, _, ok := runtime.Caller(1)
-	if !ok {
-		return diags.Append(err)
+// Decode implements DynamicValueCodec.
+func (MsgPackCodec) Decode(v *dynamicValue, ty cty.Type) (cty.Value, error) {
+	return decodeDynamicValue(v, ty)
+}
+
+// JSONCodec is a DynamicValueCodec that emits and expects the JSON
+// encoding rather than MsgPack. Captured JSON request/response payloads
+// are human-readable, which is useful for debugging a failing import or
+// for recording fixtures to replay in tests of the import pipeline.
+type JSONCodec struct{}
+
+// Encode implements DynamicValueCodec.
+func (JSONCodec) Encode(v cty.Value, ty cty.Type) (*dynamicValue, error) {
+	j, err := ctyjson.Marshal(v, ty)
+	if err != nil {
+		return nil, err
 	}
+	return &dynamicValue{JSON: j}, nil
+}
+
+// Decode implements DynamicValueCodec.
+func (JSONCodec) Decode(v *dynamicValue, ty cty.Type) (cty.Value, error) {
+	return decodeDynamicValue(v, ty)
+}
+
+// validateDecoded walks v, a value just decoded off the wire, against
+// block's declared attributes and nested blocks. Any top-level value that
+// decoded as null or unknown is replaced with the canonical
+// cty.NullVal/UnknownVal for its declared type, and any value that cannot
+// convert to that type produces a diagnostic rather than being passed on
+// as-is. Nested blocks (the `ingress { ... }`/`tags { ... }`-style blocks
+// SDKv2 schemas declare via configschema.Block.BlockTypes, as distinct from
+// the NestedType attributes protocol v6 uses) are validated the same way,
+// recursively, via validateNestedBlock.
+func validateDecoded(v cty.Value, block *configschema.Block) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	if block == nil || v.IsNull() || !v.IsKnown() || !v.CanIterateElements() {
+		return v, diags
+	}
+
+	attrs := make(map[string]cty.Value)
+	for it := v.ElementIterator(); it.Next(); {
+		k, av := it.Element()
+		name := k.AsString()
 
-	f := runtime.FuncForPC(pc)
+		if attr, ok := block.Attributes[name]; ok {
+			// A NestedType attribute (protocol v6 only) carries its type in
+			// NestedType.ImpliedType rather than Type, which is left as the
+			// zero cty.Type{}. Converting against that zero type would fail
+			// for every such attribute, so fall back to the implied type
+			// instead of recursing into the nested object's own attributes.
+			attrType := attr.Type
+			if attr.NestedType != nil {
+				attrType = attr.NestedType.ImpliedType()
+			}
+
+			switch {
+			case av.IsNull():
+				attrs[name] = cty.NullVal(attrType)
+			case !av.IsKnown():
+				attrs[name] = cty.UnknownVal(attrType)
+			default:
+				converted, err := convert.Convert(av, attrType)
+				if err != nil {
+					diags = diags.Append(tfdiags.Sourceless(
+						tfdiags.Error,
+						"Invalid attribute value",
+						fmt.Sprintf("Attribute %q does not conform to its declared type: %s.", name, err),
+					))
+					attrs[name] = av
+					continue
+				}
+				attrs[name] = converted
+			}
+			continue
+		}
+
+		if nested, ok := block.BlockTypes[name]; ok {
+			converted, blockDiags := validateNestedBlock(av, nested)
+			diags = diags.Append(blockDiags)
+			attrs[name] = converted
+			continue
+		}
+
+		attrs[name] = av
+	}
+
+	return cty.ObjectVal(attrs), diags
+}
 
-	// Function names will contain the full import path. Take the last
-	// segment, which will let users know which method was being called.
-	_, requestName := path.Split(f.Name())
+// validateNestedBlock applies validateDecoded's null/unknown canonicalization
+// and type-conversion checks to a single nested block, recursing into its
+// own attributes and nested blocks via validateDecoded. Unlike an Attribute,
+// a NestedBlock's instances are wrapped in a list/set/map/single collection
+// according to its Nesting mode, so the collection is rebuilt around the
+// validated instances rather than converted directly.
+func validateNestedBlock(v cty.Value, nested *configschema.NestedBlock) (cty.Value, tfdiags.Diagnostics) {
+	var diags tfdiags.Diagnostics
+	elemType := nested.Block.ImpliedType()
+
+	switch nested.Nesting {
+	case configschema.NestingSingle, configschema.NestingGroup:
+		switch {
+		case v.IsNull():
+			return cty.NullVal(elemType), diags
+		case !v.IsKnown():
+			return cty.UnknownVal(elemType), diags
+		default:
+			return validateDecoded(v, &nested.Block)
+		}
+	case configschema.NestingMap:
+		switch {
+		case v.IsNull():
+			return cty.NullVal(cty.Map(elemType)), diags
+		case !v.IsKnown() || !v.CanIterateElements():
+			return v, diags
+		}
+		elems := make(map[string]cty.Value)
+		for it := v.ElementIterator(); it.Next(); {
+			k, ev := it.Element()
+			converted, elemDiags := validateDecoded(ev, &nested.Block)
+			diags = diags.Append(elemDiags)
+			elems[k.AsString()] = converted
+		}
+		if len(elems) == 0 {
+			return cty.MapValEmpty(elemType), diags
+		}
+		return cty.MapVal(elems), diags
+	default: // NestingList, NestingSet
+		switch {
+		case v.IsNull():
+			if nested.Nesting == configschema.NestingSet {
+				return cty.NullVal(cty.Set(elemType)), diags
+			}
+			return cty.NullVal(cty.List(elemType)), diags
+		case !v.IsKnown() || !v.CanIterateElements():
+			return v, diags
+		}
+		var elems []cty.Value
+		for it := v.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			converted, elemDiags := validateDecoded(ev, &nested.Block)
+			diags = diags.Append(elemDiags)
+			elems = append(elems, converted)
+		}
+		if nested.Nesting == configschema.NestingSet {
+			if len(elems) == 0 {
+				return cty.SetValEmpty(elemType), diags
+			}
+			return cty.SetVal(elems), diags
+		}
+		if len(elems) == 0 {
+			return cty.ListValEmpty(elemType), diags
+		}
+		return cty.ListVal(elems), diags
+	}
+}
+
+// grpcErr extracts some known error types and formats them into better
+// representations for core, recording them through d.record so the result
+// identifies the plugin and resource type the call was against instead of
+// being a bare unattributed error. requestName is the public Terraform-facing
+// operation the caller was servicing (e.g. "ReadResource"); it is passed in
+// explicitly rather than recovered from the call stack so the message stays
+// accurate regardless of how many internal helpers sit between the plugin
+// call and the exported entry point. Since we don't use RPC status errors
+// for the plugin protocol, these do not contain any useful details beyond
+// their code, so we return some text that at least indicates the plugin
+// call and error condition.
+func (d *diagnostics) grpcErr(requestName, resourceType string, err error) (diags tfdiags.Diagnostics) {
+	if err == nil {
+		return
+	}
 
-	// TODO: while this expands the error codes into somewhat better messages,
-	// this still does not easily link the error to an actual user-recognizable
-	// plugin. The grpc plugin does not know its configured name, and the
-	// errors are in a list of diagnostics, making it hard for the caller to
-	// annotate the returned errors.
 	switch status.Code(err) {
 	case codes.Unavailable:
 		// This case is when the plugin has stopped running for some reason,
 		// and is usually the result of a crash.
-		diags = diags.Append(tfdiags.Sourceless(
-			tfdiags.Error,
-			"Plugin did not respond",
+		diags = diags.Append(d.record(resourceType, tfdiags.Error, "Plugin did not respond",
 			fmt.Sprintf("The plugin encountered an error, and failed to respond to the %s call. "+
-				"The plugin logs may contain more details.", requestName),
-		))
+				"The plugin logs may contain more details.", requestName), nil))
 	case codes.Canceled:
-		diags = diags.Append(tfdiags.Sourceless(
-			tfdiags.Error,
-			"Request cancelled",
-			fmt.Sprintf("The %s request was cancelled.", requestName),
-		))
+		diags = diags.Append(d.record(resourceType, tfdiags.Error, "Request cancelled",
+			fmt.Sprintf("The %s request was cancelled.", requestName), nil))
 	case codes.Unimplemented:
-		diags = diags.Append(tfdiags.Sourceless(
-			tfdiags.Error,
-			"Unsupported plugin method",
-			fmt.Sprintf("The %s method is not supported by this plugin.", requestName),
-		))
+		diags = diags.Append(d.record(resourceType, tfdiags.Error, "Unsupported plugin method",
+			fmt.Sprintf("The %s method is not supported by this plugin.", requestName), nil))
 	default:
-		diags = diags.Append(tfdiags.Sourceless(
-			tfdiags.Error,
-			"Plugin error",
-			fmt.Sprintf("The plugin returned an unexpected error from %s: %v", requestName, err),
-		))
+		diags = diags.Append(d.record(resourceType, tfdiags.Error, "Plugin error",
+			fmt.Sprintf("The plugin returned an unexpected error from %s: %v", requestName, err), nil))
 	}
 	return
 }
@@ -0,0 +1,72 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	"github.com/hashicorp/terraform/providers"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsRetryableTransportErr(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"unavailable", status.Error(codes.Unavailable, "plugin crashed"), true},
+		{"unimplemented", status.Error(codes.Unimplemented, "nope"), false},
+		{"plain error", errors.New("boom"), false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := isRetryableTransportErr(tc.err); got != tc.want {
+				t.Fatalf("isRetryableTransportErr(%v) = %v, want %v", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestImportBatchCancelledBeforeStart(t *testing.T) {
+	c := NewGRPCClient("test", &schema.Provider{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reqs := []providers.ImportResourceStateRequest{
+		{TypeName: "test_resource", ID: "abc"},
+	}
+
+	results, diags := c.ImportBatch(ctx, reqs, 2)
+	if len(results) != 0 {
+		t.Fatalf("expected no results for a cancelled batch, got %d", len(results))
+	}
+	addr := "test_resource.abc"
+	if !diags[addr].HasErrors() {
+		t.Fatalf("expected a cancellation diagnostic for %s", addr)
+	}
+}
+
+func TestReadBatchCancelledBeforeStart(t *testing.T) {
+	c := NewGRPCClient("test", &schema.Provider{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reqs := []providers.ReadResourceRequest{
+		{TypeName: "test_resource"},
+	}
+
+	results, diags := c.ReadBatch(ctx, reqs, 2)
+	if len(results) != 0 {
+		t.Fatalf("expected no results for a cancelled batch, got %d", len(results))
+	}
+	if !diags[0].HasErrors() {
+		t.Fatalf("expected a cancellation diagnostic for request 0")
+	}
+}
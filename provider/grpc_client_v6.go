@@ -0,0 +1,674 @@
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/hashicorp/terraform/providers"
+	"github.com/hashicorp/terraform/tfdiags"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// GRPCClientV6 is an inmemory implementation of the TF GRPC protocol v6
+// client. It mirrors GRPCClient but talks to a tfprotov6.ProviderServer
+// directly, which is what providers built on terraform-plugin-go's v6
+// server implementation (and the nested-block schemas that come with it)
+// expose.
+type GRPCClientV6 struct {
+	NopProvider
+	server tfprotov6.ProviderServer
+
+	mu      sync.Mutex
+	schemas providers.GetSchemaResponse
+
+	diagnostics
+}
+
+// NewGRPCClientV6 wraps a protocol v6 provider server. providerName is
+// stored on the client and stamped onto every ProviderDiagnostic it
+// produces.
+func NewGRPCClientV6(providerName string, server tfprotov6.ProviderServer, opts ...ClientOption) *GRPCClientV6 {
+	c := &GRPCClientV6{
+		server:      server,
+		diagnostics: diagnostics{providerName: providerName, codec: MsgPackCodec{}},
+	}
+	for _, opt := range opts {
+		opt(&c.diagnostics)
+	}
+	return c
+}
+
+func (c *GRPCClientV6) ReadResource(r providers.ReadResourceRequest) providers.ReadResourceResponse {
+	resp, _ := c.readResource(context.Background(), r)
+	return resp
+}
+
+// readResource is the context-aware implementation behind ReadResource; see
+// GRPCClient.readResource for why it is split out and what the transportErr
+// return is for.
+func (c *GRPCClientV6) readResource(ctx context.Context, r providers.ReadResourceRequest) (resp providers.ReadResourceResponse, transportErr error) {
+	resSchema := c.getResourceSchema(r.TypeName)
+	metaSchema := c.getProviderMetaSchema()
+
+	currentState, err := c.codec.Encode(r.PriorState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+
+	protoReq := &tfprotov6.ReadResourceRequest{
+		TypeName:     r.TypeName,
+		CurrentState: dynamicValueToV6(currentState),
+		Private:      r.Private,
+	}
+
+	if metaSchema.Block != nil {
+		providerMeta, err := c.codec.Encode(r.ProviderMeta, metaSchema.Block.ImpliedType())
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp, nil
+		}
+		protoReq.ProviderMeta = dynamicValueToV6(providerMeta)
+	}
+
+	protoResp, err := c.server.ReadResource(ctx, protoReq)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("ReadResource", r.TypeName, err))
+		return resp, err
+	}
+	for _, d := range protoResp.Diagnostics {
+		pd := c.record(r.TypeName, severityFromProtoV6(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
+	}
+
+	state, err := c.codec.Decode(dynamicValueFromV6(protoResp.NewState), resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	state, valDiags := validateDecoded(state, resSchema.Block)
+	resp.Diagnostics = resp.Diagnostics.Append(valDiags)
+	resp.NewState = state
+	resp.Private = protoResp.Private
+
+	return resp, nil
+}
+
+func (c *GRPCClientV6) ImportResourceState(r providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	resp, _ := c.importResourceState(context.Background(), r)
+	return resp
+}
+
+// importResourceState is the context-aware implementation behind
+// ImportResourceState; see GRPCClient.readResource for why it is split out
+// and what the transportErr return is for.
+func (c *GRPCClientV6) importResourceState(ctx context.Context, r providers.ImportResourceStateRequest) (resp providers.ImportResourceStateResponse, transportErr error) {
+	protoReq := &tfprotov6.ImportResourceStateRequest{
+		TypeName: r.TypeName,
+		ID:       r.ID,
+	}
+
+	protoResp, err := c.server.ImportResourceState(ctx, protoReq)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("ImportResourceState", r.TypeName, err))
+		return resp, err
+	}
+	for _, d := range protoResp.Diagnostics {
+		pd := c.record(r.TypeName, severityFromProtoV6(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
+	}
+
+	for _, imported := range protoResp.ImportedResources {
+		resource := providers.ImportedResource{
+			TypeName: imported.TypeName,
+			Private:  imported.Private,
+		}
+
+		resSchema := c.getResourceSchema(resource.TypeName)
+		state, err := c.codec.Decode(dynamicValueFromV6(imported.State), resSchema.Block.ImpliedType())
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp, nil
+		}
+		state, valDiags := validateDecoded(state, resSchema.Block)
+		resp.Diagnostics = resp.Diagnostics.Append(valDiags)
+		resource.State = state
+		resp.ImportedResources = append(resp.ImportedResources, resource)
+	}
+
+	return resp, nil
+}
+
+func (c *GRPCClientV6) PlanResourceChange(r providers.PlanResourceChangeRequest) providers.PlanResourceChangeResponse {
+	resp, _ := c.planResourceChange(context.Background(), r)
+	return resp
+}
+
+// planResourceChange is the context-aware implementation behind
+// PlanResourceChange; see GRPCClient.readResource for why it is split out
+// and what the transportErr return is for.
+func (c *GRPCClientV6) planResourceChange(ctx context.Context, r providers.PlanResourceChangeRequest) (resp providers.PlanResourceChangeResponse, transportErr error) {
+	resSchema := c.getResourceSchema(r.TypeName)
+	metaSchema := c.getProviderMetaSchema()
+
+	priorState, err := c.codec.Encode(r.PriorState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	proposedNewState, err := c.codec.Encode(r.ProposedNewState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	config, err := c.codec.Encode(r.Config, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+
+	protoReq := &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         r.TypeName,
+		PriorState:       dynamicValueToV6(priorState),
+		ProposedNewState: dynamicValueToV6(proposedNewState),
+		Config:           dynamicValueToV6(config),
+		PriorPrivate:     r.PriorPrivate,
+	}
+
+	if metaSchema.Block != nil {
+		providerMeta, err := c.codec.Encode(r.ProviderMeta, metaSchema.Block.ImpliedType())
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp, nil
+		}
+		protoReq.ProviderMeta = dynamicValueToV6(providerMeta)
+	}
+
+	protoResp, err := c.server.PlanResourceChange(ctx, protoReq)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("PlanResourceChange", r.TypeName, err))
+		return resp, err
+	}
+	for _, d := range protoResp.Diagnostics {
+		pd := c.record(r.TypeName, severityFromProtoV6(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
+	}
+
+	state, err := c.codec.Decode(dynamicValueFromV6(protoResp.PlannedState), resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	state, valDiags := validateDecoded(state, resSchema.Block)
+	resp.Diagnostics = resp.Diagnostics.Append(valDiags)
+	resp.PlannedState = state
+	resp.PlannedPrivate = protoResp.PlannedPrivate
+	resp.LegacyTypeSystem = protoResp.UnsafeToUseLegacyTypeSystem
+	for _, p := range protoResp.RequiresReplace {
+		path, err := p.ToTerraformPath()
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			continue
+		}
+		resp.RequiresReplace = append(resp.RequiresReplace, path)
+	}
+
+	return resp, nil
+}
+
+func (c *GRPCClientV6) ApplyResourceChange(r providers.ApplyResourceChangeRequest) providers.ApplyResourceChangeResponse {
+	resp, _ := c.applyResourceChange(context.Background(), r)
+	return resp
+}
+
+// applyResourceChange is the context-aware implementation behind
+// ApplyResourceChange; see GRPCClient.readResource for why it is split out
+// and what the transportErr return is for.
+func (c *GRPCClientV6) applyResourceChange(ctx context.Context, r providers.ApplyResourceChangeRequest) (resp providers.ApplyResourceChangeResponse, transportErr error) {
+	resSchema := c.getResourceSchema(r.TypeName)
+	metaSchema := c.getProviderMetaSchema()
+
+	priorState, err := c.codec.Encode(r.PriorState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	plannedState, err := c.codec.Encode(r.PlannedState, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	config, err := c.codec.Encode(r.Config, resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+
+	protoReq := &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:       r.TypeName,
+		PriorState:     dynamicValueToV6(priorState),
+		PlannedState:   dynamicValueToV6(plannedState),
+		Config:         dynamicValueToV6(config),
+		PlannedPrivate: r.PlannedPrivate,
+	}
+
+	if metaSchema.Block != nil {
+		providerMeta, err := c.codec.Encode(r.ProviderMeta, metaSchema.Block.ImpliedType())
+		if err != nil {
+			resp.Diagnostics = resp.Diagnostics.Append(err)
+			return resp, nil
+		}
+		protoReq.ProviderMeta = dynamicValueToV6(providerMeta)
+	}
+
+	protoResp, err := c.server.ApplyResourceChange(ctx, protoReq)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("ApplyResourceChange", r.TypeName, err))
+		return resp, err
+	}
+	for _, d := range protoResp.Diagnostics {
+		pd := c.record(r.TypeName, severityFromProtoV6(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
+	}
+
+	state, err := c.codec.Decode(dynamicValueFromV6(protoResp.NewState), resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	state, valDiags := validateDecoded(state, resSchema.Block)
+	resp.Diagnostics = resp.Diagnostics.Append(valDiags)
+	resp.NewState = state
+	resp.Private = protoResp.Private
+	resp.LegacyTypeSystem = protoResp.UnsafeToUseLegacyTypeSystem
+
+	return resp, nil
+}
+
+// UpgradeResourceState forwards to the wrapped tfprotov6.ProviderServer so
+// that a resource instance state saved by an older schema version can be
+// brought up to date with the provider's current schema before it is used
+// for any further processing. This replaces the no-op inherited from
+// NopProvider, which returned an empty response with no upgraded state, mirroring
+// GRPCClient.UpgradeResourceState.
+func (c *GRPCClientV6) UpgradeResourceState(r providers.UpgradeResourceStateRequest) providers.UpgradeResourceStateResponse {
+	resp, _ := c.upgradeResourceState(context.Background(), r)
+	return resp
+}
+
+// upgradeResourceState is the context-aware implementation behind
+// UpgradeResourceState; see GRPCClient.readResource for why it is split out
+// and what the transportErr return is for.
+func (c *GRPCClientV6) upgradeResourceState(ctx context.Context, r providers.UpgradeResourceStateRequest) (resp providers.UpgradeResourceStateResponse, transportErr error) {
+	resSchema := c.getResourceSchema(r.TypeName)
+
+	protoReq := &tfprotov6.UpgradeResourceStateRequest{
+		TypeName: r.TypeName,
+		Version:  int64(r.Version),
+		RawState: &tfprotov6.RawState{
+			JSON:    r.RawStateJSON,
+			Flatmap: r.RawStateFlatmap,
+		},
+	}
+
+	protoResp, err := c.server.UpgradeResourceState(ctx, protoReq)
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(c.grpcErr("UpgradeResourceState", r.TypeName, err))
+		return resp, err
+	}
+	for _, d := range protoResp.Diagnostics {
+		pd := c.record(r.TypeName, severityFromProtoV6(d.Severity), d.Summary, d.Detail, d.Attribute)
+		resp.Diagnostics = resp.Diagnostics.Append(pd)
+	}
+
+	state, err := c.codec.Decode(dynamicValueFromV6(protoResp.UpgradedState), resSchema.Block.ImpliedType())
+	if err != nil {
+		resp.Diagnostics = resp.Diagnostics.Append(err)
+		return resp, nil
+	}
+	state, valDiags := validateDecoded(state, resSchema.Block)
+	resp.Diagnostics = resp.Diagnostics.Append(valDiags)
+	resp.UpgradedState = state
+
+	return resp, nil
+}
+
+// ImportBatch runs ImportResourceState for each request in reqs, fanning
+// the work out across concurrency goroutines; see GRPCClient.ImportBatch
+// for the cancellation/retry semantics, which are identical here.
+func (c *GRPCClientV6) ImportBatch(ctx context.Context, reqs []providers.ImportResourceStateRequest, concurrency int) (map[string]providers.ImportResourceStateResponse, map[string]tfdiags.Diagnostics) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make(map[string]providers.ImportResourceStateResponse, len(reqs))
+	diags := make(map[string]tfdiags.Diagnostics)
+	var mu sync.Mutex
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+reqLoop:
+	for _, r := range reqs {
+		r := r
+		addr := r.TypeName + "." + r.ID
+
+		// See GRPCClient.ImportBatch for why ctx is checked before the select.
+		acquired := false
+		if ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case sem <- struct{}{}:
+				acquired = true
+			}
+		}
+		if !acquired {
+			mu.Lock()
+			diags[addr] = diags[addr].Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Import cancelled",
+				"The import of "+addr+" was cancelled before it started.",
+			))
+			mu.Unlock()
+			continue reqLoop
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp := c.importResourceStateWithRetry(ctx, r)
+
+			mu.Lock()
+			results[addr] = resp
+			if resp.Diagnostics.HasErrors() {
+				diags[addr] = resp.Diagnostics
+			}
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results, diags
+}
+
+// importResourceStateWithRetry mirrors GRPCClient.importResourceStateWithRetry.
+func (c *GRPCClientV6) importResourceStateWithRetry(ctx context.Context, r providers.ImportResourceStateRequest) providers.ImportResourceStateResponse {
+	backoff := importBatchBaseBackoff
+	for attempt := 0; ; attempt++ {
+		resp, transportErr := c.importResourceState(ctx, r)
+		if !isRetryableTransportErr(transportErr) || attempt >= importBatchRetries {
+			return resp
+		}
+
+		select {
+		case <-ctx.Done():
+			return resp
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// ReadBatch runs ReadResource for each request in reqs, fanning the work
+// out across concurrency goroutines; see GRPCClient.ReadBatch for the
+// cancellation/retry semantics and why results are keyed by index rather
+// than resource address.
+func (c *GRPCClientV6) ReadBatch(ctx context.Context, reqs []providers.ReadResourceRequest, concurrency int) (map[int]providers.ReadResourceResponse, map[int]tfdiags.Diagnostics) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make(map[int]providers.ReadResourceResponse, len(reqs))
+	diags := make(map[int]tfdiags.Diagnostics)
+	var mu sync.Mutex
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+reqLoop:
+	for i, r := range reqs {
+		i, r := i, r
+
+		// See GRPCClient.ImportBatch for why ctx is checked before the select.
+		acquired := false
+		if ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case sem <- struct{}{}:
+				acquired = true
+			}
+		}
+		if !acquired {
+			mu.Lock()
+			diags[i] = diags[i].Append(tfdiags.Sourceless(
+				tfdiags.Error,
+				"Read cancelled",
+				"The read of "+r.TypeName+" was cancelled before it started.",
+			))
+			mu.Unlock()
+			continue reqLoop
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp := c.readResourceWithRetry(ctx, r)
+
+			mu.Lock()
+			results[i] = resp
+			if resp.Diagnostics.HasErrors() {
+				diags[i] = resp.Diagnostics
+			}
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results, diags
+}
+
+// readResourceWithRetry mirrors GRPCClient.readResourceWithRetry.
+func (c *GRPCClientV6) readResourceWithRetry(ctx context.Context, r providers.ReadResourceRequest) providers.ReadResourceResponse {
+	backoff := importBatchBaseBackoff
+	for attempt := 0; ; attempt++ {
+		resp, transportErr := c.readResource(ctx, r)
+		if !isRetryableTransportErr(transportErr) || attempt >= importBatchRetries {
+			return resp
+		}
+
+		select {
+		case <-ctx.Done():
+			return resp
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// getSchema is used internally to get the saved provider schema, converting
+// from the protocol v6 wire types (which support the nested-block schema
+// shapes v6 introduces) to the version-agnostic providers.GetSchemaResponse
+// the rest of the client operates on. It is synchronized for the same
+// reasons as GRPCClient.getSchema.
+func (c *GRPCClientV6) getSchema() providers.GetSchemaResponse {
+	c.mu.Lock()
+	if c.schemas.Provider.Block != nil {
+		c.mu.Unlock()
+		return c.schemas
+	}
+	c.mu.Unlock()
+
+	protoSchema, err := c.server.GetProviderSchema(context.Background(), &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		panic(err)
+	}
+
+	schemas := providers.GetSchemaResponse{
+		ResourceTypes: make(map[string]providers.Schema, len(protoSchema.ResourceSchemas)),
+		DataSources:   make(map[string]providers.Schema, len(protoSchema.DataSourceSchemas)),
+	}
+	if protoSchema.Provider != nil {
+		schemas.Provider = schemaFromProtoV6(protoSchema.Provider)
+	}
+	if protoSchema.ProviderMeta != nil {
+		schemas.ProviderMeta = schemaFromProtoV6(protoSchema.ProviderMeta)
+	}
+	for name, s := range protoSchema.ResourceSchemas {
+		schemas.ResourceTypes[name] = schemaFromProtoV6(s)
+	}
+	for name, s := range protoSchema.DataSourceSchemas {
+		schemas.DataSources[name] = schemaFromProtoV6(s)
+	}
+
+	c.mu.Lock()
+	c.schemas = schemas
+	c.mu.Unlock()
+
+	return schemas
+}
+
+// getResourceSchema is a helper to extract the schema for a resource, and
+// panics if the schema is not available.
+func (c *GRPCClientV6) getResourceSchema(name string) providers.Schema {
+	schema := c.getSchema()
+	resSchema, ok := schema.ResourceTypes[name]
+	if !ok {
+		panic("unknown resource type " + name)
+	}
+	return resSchema
+}
+
+// getProviderMetaSchema is a helper to extract the schema for the meta info
+// defined for a provider,
+func (c *GRPCClientV6) getProviderMetaSchema() providers.Schema {
+	schema := c.getSchema()
+	return schema.ProviderMeta
+}
+
+// schemaFromProtoV6 converts a protocol v6 schema, including its nested
+// blocks, into the providers.Schema shape used throughout the rest of the
+// client.
+func schemaFromProtoV6(s *tfprotov6.Schema) providers.Schema {
+	return providers.Schema{
+		Version: s.Version,
+		Block:   blockFromProtoV6(s.Block),
+	}
+}
+
+// blockFromProtoV6 converts a protocol v6 SchemaBlock into a
+// configschema.Block, recursing into both the legacy nested BlockTypes and
+// the NestedType attributes that v6 adds for representing nested objects.
+func blockFromProtoV6(b *tfprotov6.SchemaBlock) *configschema.Block {
+	block := &configschema.Block{
+		Attributes: make(map[string]*configschema.Attribute, len(b.Attributes)),
+		BlockTypes: make(map[string]*configschema.NestedBlock, len(b.BlockTypes)),
+	}
+
+	for _, a := range b.Attributes {
+		block.Attributes[a.Name] = attributeFromProtoV6(a)
+	}
+
+	for _, nb := range b.BlockTypes {
+		block.BlockTypes[nb.TypeName] = &configschema.NestedBlock{
+			Block:    *blockFromProtoV6(nb.Block),
+			Nesting:  nestingModeFromProtoV6(nb.Nesting),
+			MinItems: int(nb.MinItems),
+			MaxItems: int(nb.MaxItems),
+		}
+	}
+
+	return block
+}
+
+// attributeFromProtoV6 converts a single protocol v6 attribute, including
+// the NestedType object schema v6 introduces for representing structured
+// attributes without a full nested block.
+func attributeFromProtoV6(a *tfprotov6.SchemaAttribute) *configschema.Attribute {
+	attr := &configschema.Attribute{
+		Description: a.Description,
+		Required:    a.Required,
+		Optional:    a.Optional,
+		Computed:    a.Computed,
+		Sensitive:   a.Sensitive,
+	}
+
+	if a.NestedType != nil {
+		attr.NestedType = objectFromProtoV6(a.NestedType)
+		return attr
+	}
+
+	ty, err := a.Type.MarshalJSON()
+	if err != nil {
+		panic(err)
+	}
+	ctyType, err := ctyjson.UnmarshalType(ty)
+	if err != nil {
+		panic(err)
+	}
+	attr.Type = ctyType
+
+	return attr
+}
+
+// objectFromProtoV6 converts a protocol v6 NestedType object schema into a
+// configschema.Object, recursing so that nested objects of any depth are
+// supported.
+func objectFromProtoV6(o *tfprotov6.SchemaObject) *configschema.Object {
+	obj := &configschema.Object{
+		Attributes: make(map[string]*configschema.Attribute, len(o.Attributes)),
+		Nesting:    nestingModeFromProtoV6(o.Nesting),
+	}
+	for _, a := range o.Attributes {
+		obj.Attributes[a.Name] = attributeFromProtoV6(a)
+	}
+	return obj
+}
+
+// nestingModeFromProtoV6 maps the protocol v6 nesting mode enums (shared
+// between SchemaObject and SchemaNestedBlock) onto configschema.NestingMode.
+func nestingModeFromProtoV6(m interface{}) configschema.NestingMode {
+	switch v := m.(type) {
+	case tfprotov6.SchemaObjectNestingMode:
+		switch v {
+		case tfprotov6.SchemaObjectNestingModeSingle:
+			return configschema.NestingSingle
+		case tfprotov6.SchemaObjectNestingModeList:
+			return configschema.NestingList
+		case tfprotov6.SchemaObjectNestingModeSet:
+			return configschema.NestingSet
+		case tfprotov6.SchemaObjectNestingModeMap:
+			return configschema.NestingMap
+		}
+	case tfprotov6.SchemaNestedBlockNestingMode:
+		switch v {
+		case tfprotov6.SchemaNestedBlockNestingModeSingle:
+			return configschema.NestingSingle
+		case tfprotov6.SchemaNestedBlockNestingModeList:
+			return configschema.NestingList
+		case tfprotov6.SchemaNestedBlockNestingModeSet:
+			return configschema.NestingSet
+		case tfprotov6.SchemaNestedBlockNestingModeMap:
+			return configschema.NestingMap
+		case tfprotov6.SchemaNestedBlockNestingModeGroup:
+			return configschema.NestingGroup
+		}
+	}
+	return configschema.NestingSingle
+}
+
+// severityFromProtoV6 maps a protocol v6 diagnostic severity onto the
+// tfdiags.Severity used throughout terraform core, mirroring
+// severityFromProtoV5.
+func severityFromProtoV6(s tfprotov6.DiagnosticSeverity) tfdiags.Severity {
+	if s == tfprotov6.DiagnosticSeverityWarning {
+		return tfdiags.Warning
+	}
+	return tfdiags.Error
+}
@@ -0,0 +1,114 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/configs/configschema"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func testValidateDecodedBlock() *configschema.Block {
+	return &configschema.Block{
+		Attributes: map[string]*configschema.Attribute{
+			"name": {Type: cty.String, Optional: true},
+			"meta": {
+				NestedType: &configschema.Object{
+					Attributes: map[string]*configschema.Attribute{
+						"id": {Type: cty.String, Computed: true},
+					},
+					Nesting: configschema.NestingSingle,
+				},
+				Optional: true,
+			},
+		},
+		BlockTypes: map[string]*configschema.NestedBlock{
+			"ingress": {
+				Block: configschema.Block{
+					Attributes: map[string]*configschema.Attribute{
+						"port": {Type: cty.Number, Optional: true},
+					},
+				},
+				Nesting: configschema.NestingList,
+			},
+		},
+	}
+}
+
+func TestValidateDecodedConvertsMismatchedType(t *testing.T) {
+	v := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.NumberIntVal(1),
+		"meta": cty.NullVal(cty.DynamicPseudoType),
+	})
+
+	got, diags := validateDecoded(v, testValidateDecodedBlock())
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+	if got.GetAttr("name") != cty.StringVal("1") {
+		t.Fatalf("expected name to convert to \"1\", got %#v", got.GetAttr("name"))
+	}
+}
+
+func TestValidateDecodedFlagsInconvertibleType(t *testing.T) {
+	v := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.ListValEmpty(cty.String),
+		"meta": cty.NullVal(cty.DynamicPseudoType),
+	})
+
+	_, diags := validateDecoded(v, testValidateDecodedBlock())
+	if !diags.HasErrors() {
+		t.Fatalf("expected a diagnostic for an inconvertible attribute")
+	}
+}
+
+func TestValidateDecodedHandlesNestedType(t *testing.T) {
+	v := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.NullVal(cty.String),
+		"meta": cty.ObjectVal(map[string]cty.Value{
+			"id": cty.StringVal("abc"),
+		}),
+	})
+
+	_, diags := validateDecoded(v, testValidateDecodedBlock())
+	if diags.HasErrors() {
+		t.Fatalf("a NestedType attribute should convert against its implied type, not error: %s", diags.Err())
+	}
+}
+
+func TestValidateDecodedConvertsNestedBlockList(t *testing.T) {
+	v := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.NullVal(cty.String),
+		"meta": cty.NullVal(cty.DynamicPseudoType),
+		"ingress": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"port": cty.NumberIntVal(80),
+			}),
+		}),
+	})
+
+	got, diags := validateDecoded(v, testValidateDecodedBlock())
+	if diags.HasErrors() {
+		t.Fatalf("unexpected diagnostics: %s", diags.Err())
+	}
+	ingress := got.GetAttr("ingress")
+	if ingress.LengthInt() != 1 {
+		t.Fatalf("expected one ingress block, got %#v", ingress)
+	}
+}
+
+func TestValidateDecodedFlagsInconvertibleNestedBlockAttribute(t *testing.T) {
+	v := cty.ObjectVal(map[string]cty.Value{
+		"name": cty.NullVal(cty.String),
+		"meta": cty.NullVal(cty.DynamicPseudoType),
+		"ingress": cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{
+				"port": cty.ListValEmpty(cty.String),
+			}),
+		}),
+	})
+
+	_, diags := validateDecoded(v, testValidateDecodedBlock())
+	if !diags.HasErrors() {
+		t.Fatalf("expected a diagnostic for an inconvertible nested block attribute")
+	}
+}